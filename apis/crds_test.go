@@ -0,0 +1,69 @@
+/*
+Copyright 2022 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package apis
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	extv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"sigs.k8s.io/yaml"
+)
+
+// TestCRDNames ensures the generated CRDs reflect the shortName and
+// categories markers of every type.
+func TestCRDNames(t *testing.T) {
+	files, err := filepath.Glob(filepath.Join("..", "package", "crds", "*.yaml"))
+	if err != nil {
+		t.Fatalf("cannot find CRDs: %s", err)
+	}
+	if len(files) == 0 {
+		t.Fatal("no CRDs found")
+	}
+
+	for _, f := range files {
+		t.Run(filepath.Base(f), func(t *testing.T) {
+			b, err := os.ReadFile(filepath.Clean(f))
+			if err != nil {
+				t.Fatalf("cannot read CRD: %s", err)
+			}
+			crd := &extv1.CustomResourceDefinition{}
+			if err := yaml.Unmarshal(b, crd); err != nil {
+				t.Fatalf("cannot unmarshal CRD: %s", err)
+			}
+
+			if len(crd.Spec.Names.ShortNames) == 0 {
+				t.Errorf("%s has no shortNames", crd.GetName())
+			}
+			for _, want := range []string{"crossplane", "template"} {
+				if !contains(crd.Spec.Names.Categories, want) {
+					t.Errorf("%s is not in category %q", crd.GetName(), want)
+				}
+			}
+		})
+	}
+}
+
+func contains(ss []string, s string) bool {
+	for _, v := range ss {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
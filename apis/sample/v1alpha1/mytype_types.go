@@ -45,6 +45,10 @@ type MyTypeSpec struct {
 type MyTypeStatus struct {
 	xpv1.ResourceStatus `json:",inline"`
 	AtProvider          MyTypeObservation `json:"atProvider,omitempty"`
+
+	// ObservedGeneration is the latest metadata.generation that was
+	// successfully reconciled.
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
 }
 
 // +kubebuilder:object:root=true
@@ -55,7 +59,7 @@ type MyTypeStatus struct {
 // +kubebuilder:printcolumn:name="EXTERNAL-NAME",type="string",JSONPath=".metadata.annotations.crossplane\\.io/external-name"
 // +kubebuilder:printcolumn:name="AGE",type="date",JSONPath=".metadata.creationTimestamp"
 // +kubebuilder:subresource:status
-// +kubebuilder:resource:scope=Cluster,categories={crossplane,managed,template}
+// +kubebuilder:resource:scope=Cluster,categories={crossplane,managed,template},shortName=tplmt
 type MyType struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata,omitempty"`
@@ -84,3 +88,13 @@ var (
 func init() {
 	SchemeBuilder.Register(&MyType{}, &MyTypeList{})
 }
+
+// GetObservedGeneration of this MyType.
+func (mg *MyType) GetObservedGeneration() int64 {
+	return mg.Status.ObservedGeneration
+}
+
+// SetObservedGeneration of this MyType.
+func (mg *MyType) SetObservedGeneration(g int64) {
+	mg.Status.ObservedGeneration = g
+}
@@ -19,6 +19,7 @@ package v1alpha1
 import (
 	"reflect"
 
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 
@@ -43,6 +44,31 @@ type ProviderCredentials struct {
 // A ProviderConfigStatus reflects the observed state of a ProviderConfig.
 type ProviderConfigStatus struct {
 	xpv1.ProviderConfigStatus `json:",inline"`
+
+	// LastCredentialsUseTime is the last time this provider configuration's
+	// credentials were successfully used to connect to the external API.
+	// +optional
+	LastCredentialsUseTime *metav1.Time `json:"lastCredentialsUseTime,omitempty"`
+}
+
+// Condition types and reasons of a ProviderConfig.
+const (
+	// TypeCredentialsUsed indicates whether a ProviderConfig's credentials
+	// have been successfully used to connect to the external API.
+	TypeCredentialsUsed xpv1.ConditionType = "CredentialsUsed"
+
+	ReasonConnectSuccess xpv1.ConditionReason = "ConnectSuccess"
+)
+
+// CredentialsUsed returns a condition indicating that a ProviderConfig's
+// credentials were successfully used to connect to the external API.
+func CredentialsUsed() xpv1.Condition {
+	return xpv1.Condition{
+		Type:               TypeCredentialsUsed,
+		Status:             corev1.ConditionTrue,
+		LastTransitionTime: metav1.Now(),
+		Reason:             ReasonConnectSuccess,
+	}
 }
 
 // +kubebuilder:object:root=true
@@ -51,7 +77,7 @@ type ProviderConfigStatus struct {
 // +kubebuilder:subresource:status
 // +kubebuilder:printcolumn:name="AGE",type="date",JSONPath=".metadata.creationTimestamp"
 // +kubebuilder:printcolumn:name="SECRET-NAME",type="string",JSONPath=".spec.credentials.secretRef.name",priority=1
-// +kubebuilder:resource:scope=Cluster
+// +kubebuilder:resource:scope=Cluster,categories={crossplane,provider,template},shortName=tplpc
 type ProviderConfig struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata,omitempty"`
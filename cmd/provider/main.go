@@ -20,6 +20,7 @@ import (
 	"context"
 	"os"
 	"path/filepath"
+	"strconv"
 	"time"
 
 	"gopkg.in/alecthomas/kingpin.v2"
@@ -47,11 +48,15 @@ func main() {
 		app            = kingpin.New(filepath.Base(os.Args[0]), "Template support for Crossplane.").DefaultEnvars()
 		debug          = app.Flag("debug", "Run with debug logging.").Short('d').Bool()
 		leaderElection = app.Flag("leader-election", "Use leader election for the controller manager.").Short('l').Default("false").OverrideDefaultFromEnvar("LEADER_ELECTION").Bool()
+		shutdownGrace  = app.Flag("graceful-shutdown-timeout", "How long to wait for in-flight reconciles to return when stopping. Should be shorter than the pod's termination grace period.").Default("25s").Duration()
 
 		syncInterval     = app.Flag("sync", "How often all resources will be double-checked for drift from the desired state.").Short('s').Default("1h").Duration()
 		pollInterval     = app.Flag("poll", "How often individual resources will be checked for drift from the desired state").Default("1m").Duration()
 		maxReconcileRate = app.Flag("max-reconcile-rate", "The global maximum rate per second at which resources may checked for drift from the desired state.").Default("10").Int()
 
+		pollOverrides             = app.Flag("poll-override", "Override the poll interval for a kind of resource, e.g. MyType=5m. May be repeated.").PlaceHolder("KIND=DURATION").StringMap()
+		maxReconcileRateOverrides = app.Flag("max-reconcile-rate-override", "Further limit the maximum reconcile rate per second of a kind of resource, e.g. MyType=2. May be repeated. Must not exceed --max-reconcile-rate.").PlaceHolder("KIND=RATE").StringMap()
+
 		namespace                  = app.Flag("namespace", "Namespace used to set as default scope in default secret store config.").Default("crossplane-system").Envar("POD_NAMESPACE").String()
 		enableExternalSecretStores = app.Flag("enable-external-secret-stores", "Enable support for ExternalSecretStores.").Default("false").Envar("ENABLE_EXTERNAL_SECRET_STORES").Bool()
 	)
@@ -72,6 +77,12 @@ func main() {
 	mgr, err := ctrl.NewManager(ratelimiter.LimitRESTConfig(cfg, *maxReconcileRate), ctrl.Options{
 		SyncPeriod: syncInterval,
 
+		// When the manager stops it cancels the context passed to each
+		// reconcile, then waits up to this long for in-flight reconciles
+		// to return. This lets external API calls be cleanly cancelled
+		// rather than killed mid-call when the process exits.
+		GracefulShutdownTimeout: shutdownGrace,
+
 		// controller-runtime uses both ConfigMaps and Leases for leader
 		// election by default. Leases expire after 15 seconds, with a
 		// 10 second renewal deadline. We've observed leader loss due to
@@ -115,6 +126,28 @@ func main() {
 		})), "cannot create default store config")
 	}
 
-	kingpin.FatalIfError(template.Setup(mgr, o), "Cannot setup Template controllers")
+	ko := template.KindOverrides{}
+	for kind, v := range *pollOverrides {
+		d, err := time.ParseDuration(v)
+		kingpin.FatalIfError(err, "Cannot parse poll interval override for %s", kind)
+		if d <= 0 {
+			kingpin.Fatalf("Poll interval override for %s must be positive", kind)
+		}
+		ov := ko[kind]
+		ov.PollInterval = d
+		ko[kind] = ov
+	}
+	for kind, v := range *maxReconcileRateOverrides {
+		r, err := strconv.Atoi(v)
+		kingpin.FatalIfError(err, "Cannot parse max reconcile rate override for %s", kind)
+		if r <= 0 {
+			kingpin.Fatalf("Max reconcile rate override for %s must be positive", kind)
+		}
+		ov := ko[kind]
+		ov.MaxReconcileRate = r
+		ko[kind] = ov
+	}
+
+	kingpin.FatalIfError(template.Setup(mgr, o, *maxReconcileRate, ko), "Cannot setup Template controllers")
 	kingpin.FatalIfError(mgr.Start(ctrl.SetupSignalHandler()), "Cannot start controller manager")
 }
@@ -41,3 +41,4 @@ ${GOMPLATE} < "hack/helpers/controller/KIND_LOWER/KIND_LOWER_test.go.tmpl" > "in
 
 
 
+${GOMPLATE} < "hack/helpers/controller/KIND_LOWER/cache.go.tmpl" > "internal/controller/${kind_lower}/cache.go"
@@ -0,0 +1,252 @@
+/*
+Copyright 2022 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package breaker stops calling an external API on behalf of managed
+// resources that repeatedly fail to reconcile.
+package breaker
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/types"
+
+	xpv1 "github.com/crossplane/crossplane-runtime/apis/common/v1"
+	"github.com/crossplane/crossplane-runtime/pkg/reconciler/managed"
+	"github.com/crossplane/crossplane-runtime/pkg/resource"
+
+	"github.com/crossplane/provider-template/internal/controller/gate"
+)
+
+// ReasonOpen indicates that a managed resource is not being reconciled
+// because its Breaker is open.
+const ReasonOpen xpv1.ConditionReason = "CircuitBreakerOpen"
+
+const (
+	errOpenFmt = "circuit breaker is open after %d consecutive failures: not calling the external API until %s, or until the managed resource's spec changes"
+)
+
+const (
+	defaultThreshold   = 5
+	defaultCooldown    = 1 * time.Minute
+	defaultMaxCooldown = 30 * time.Minute
+)
+
+// A Breaker tracks consecutive failures of the external API calls made to
+// reconcile each managed resource. Failures to connect to the external API,
+// for example because credentials are missing, are not counted. Once a managed
+// resource has failed to reconcile Threshold times in a row the Breaker opens,
+// and no external API calls are made on behalf of the managed resource for a
+// cooldown period. After the cooldown one reconcile is allowed
+// through. If it fails too the Breaker opens again, for twice as long. The
+// Breaker closes when a reconcile succeeds, when the managed resource's spec
+// changes, or when the managed resource is forgotten.
+type Breaker struct {
+	threshold   int
+	cooldown    time.Duration
+	maxCooldown time.Duration
+	now         func() time.Time
+
+	mu       sync.Mutex
+	failures map[types.NamespacedName]failures
+}
+
+type failures struct {
+	uid        types.UID
+	generation int64
+	count      int
+	cooldown   time.Duration
+	openUntil  time.Time
+}
+
+// An Option configures a Breaker.
+type Option func(*Breaker)
+
+// WithThreshold configures the number of consecutive failures after which a
+// Breaker opens.
+func WithThreshold(n int) Option {
+	return func(b *Breaker) {
+		b.threshold = n
+	}
+}
+
+// WithCooldown configures how long a Breaker initially stays open, and the
+// longest it may stay open.
+func WithCooldown(initial, max time.Duration) Option {
+	return func(b *Breaker) {
+		b.cooldown = initial
+		b.maxCooldown = max
+	}
+}
+
+// WithClock configures the function a Breaker uses to tell the time.
+func WithClock(now func() time.Time) Option {
+	return func(b *Breaker) {
+		b.now = now
+	}
+}
+
+// New returns a new Breaker.
+func New(o ...Option) *Breaker {
+	b := &Breaker{
+		threshold:   defaultThreshold,
+		cooldown:    defaultCooldown,
+		maxCooldown: defaultMaxCooldown,
+		now:         time.Now,
+		failures:    make(map[types.NamespacedName]failures),
+	}
+	for _, fn := range o {
+		fn(b)
+	}
+	return b
+}
+
+// Check returns a gate.Wait if the Breaker is open for the supplied managed
+// resource. It may be used as a gate.Check.
+func (b *Breaker) Check(_ context.Context, mg resource.Managed) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	f, ok := b.failures[key(mg)]
+	if !ok {
+		return nil
+	}
+	if f.uid != mg.GetUID() || f.generation != mg.GetGeneration() {
+		delete(b.failures, key(mg))
+		return nil
+	}
+	if b.now().Before(f.openUntil) {
+		return &gate.Wait{
+			Reason:  ReasonOpen,
+			Message: fmt.Sprintf(errOpenFmt, f.count, f.openUntil.Format(time.RFC3339)),
+			After:   f.openUntil.Sub(b.now()),
+		}
+	}
+	return nil
+}
+
+// Succeeded records that the supplied managed resource was reconciled
+// successfully, closing the Breaker.
+func (b *Breaker) Succeeded(mg resource.Managed) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.failures, key(mg))
+}
+
+// Forget the named managed resource, for example because it no longer exists.
+func (b *Breaker) Forget(nn types.NamespacedName) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.failures, nn)
+}
+
+// Failed records that the supplied managed resource failed to reconcile.
+func (b *Breaker) Failed(mg resource.Managed) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	f := b.failures[key(mg)]
+	if f.uid != mg.GetUID() || f.generation != mg.GetGeneration() {
+		f = failures{uid: mg.GetUID(), generation: mg.GetGeneration()}
+	}
+	f.count++
+
+	if f.count >= b.threshold {
+		switch {
+		case f.cooldown == 0:
+			f.cooldown = b.cooldown
+		case f.cooldown < b.maxCooldown:
+			f.cooldown *= 2
+		}
+		if f.cooldown > b.maxCooldown {
+			f.cooldown = b.maxCooldown
+		}
+		f.openUntil = b.now().Add(f.cooldown)
+	}
+
+	b.failures[key(mg)] = f
+}
+
+func key(mg resource.Managed) types.NamespacedName {
+	return types.NamespacedName{Namespace: mg.GetNamespace(), Name: mg.GetName()}
+}
+
+// NewConnecter wraps the supplied ExternalConnecter such that it, and the
+// ExternalClients it produces, make no external API calls while the supplied
+// Breaker is open for a managed resource.
+func NewConnecter(c managed.ExternalConnecter, b *Breaker) managed.ExternalConnecter {
+	return &connecter{inner: c, breaker: b}
+}
+
+type connecter struct {
+	inner   managed.ExternalConnecter
+	breaker *Breaker
+}
+
+func (c *connecter) Connect(ctx context.Context, mg resource.Managed) (managed.ExternalClient, error) {
+	if err := c.breaker.Check(ctx, mg); err != nil {
+		return nil, err
+	}
+	ec, err := c.inner.Connect(ctx, mg)
+	if err != nil {
+		return nil, err
+	}
+	return &external{inner: ec, breaker: c.breaker}, nil
+}
+
+// An external records the outcome of each call to the ExternalClient it
+// wraps. Create, Update, and Delete each end a reconcile, as does an Observe
+// that finds the external resource exists and is up to date.
+type external struct {
+	inner   managed.ExternalClient
+	breaker *Breaker
+}
+
+func (e *external) record(mg resource.Managed, err error) {
+	if err != nil {
+		e.breaker.Failed(mg)
+		return
+	}
+	e.breaker.Succeeded(mg)
+}
+
+func (e *external) Observe(ctx context.Context, mg resource.Managed) (managed.ExternalObservation, error) {
+	o, err := e.inner.Observe(ctx, mg)
+	if err != nil || (o.ResourceExists && o.ResourceUpToDate) {
+		e.record(mg, err)
+	}
+	return o, err
+}
+
+func (e *external) Create(ctx context.Context, mg resource.Managed) (managed.ExternalCreation, error) {
+	c, err := e.inner.Create(ctx, mg)
+	e.record(mg, err)
+	return c, err
+}
+
+func (e *external) Update(ctx context.Context, mg resource.Managed) (managed.ExternalUpdate, error) {
+	u, err := e.inner.Update(ctx, mg)
+	e.record(mg, err)
+	return u, err
+}
+
+func (e *external) Delete(ctx context.Context, mg resource.Managed) error {
+	err := e.inner.Delete(ctx, mg)
+	e.record(mg, err)
+	return err
+}
@@ -0,0 +1,313 @@
+/*
+Copyright 2022 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package breaker
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/crossplane/crossplane-runtime/pkg/reconciler/managed"
+	"github.com/crossplane/crossplane-runtime/pkg/resource"
+	"github.com/crossplane/crossplane-runtime/pkg/resource/fake"
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+
+	"github.com/crossplane/provider-template/internal/controller/gate"
+)
+
+func TestConnect(t *testing.T) {
+	errBoom := errors.New("boom")
+	start := time.Date(2022, 3, 14, 0, 0, 0, 0, time.UTC)
+
+	// A call to Connect for a managed resource of the supplied generation,
+	// the supplied time after start. The inner connecter returns the supplied
+	// connect error. If it returns no error we Observe the resource, which
+	// returns the supplied observe error or finds the resource is up to date.
+	// Either completes a reconcile.
+	type call struct {
+		generation int64
+		elapsed    time.Duration
+		connectErr error
+		observeErr error
+	}
+
+	type want struct {
+		errs     []error
+		connects int
+	}
+
+	open := func(failures int, until time.Time) error {
+		return &gate.Wait{Message: fmt.Sprintf(errOpenFmt, failures, until.Format(time.RFC3339))}
+	}
+
+	cases := map[string]struct {
+		reason string
+		calls  []call
+		want   want
+	}{
+		"TripAfterThreshold": {
+			reason: "The breaker should open after three consecutive failures.",
+			calls:  []call{{1, 0, nil, errBoom}, {1, 0, nil, errBoom}, {1, 0, nil, errBoom}, {1, time.Second, nil, errBoom}},
+			want: want{
+				errs:     []error{errBoom, errBoom, errBoom, open(3, start.Add(time.Minute))},
+				connects: 3,
+			},
+		},
+		"ConnectErrorsNotCounted": {
+			reason: "The breaker should not count failures to connect to the external API.",
+			calls:  []call{{1, 0, errBoom, nil}, {1, 0, errBoom, nil}, {1, 0, errBoom, nil}, {1, time.Second, nil, nil}},
+			want: want{
+				errs:     []error{errBoom, errBoom, errBoom, nil},
+				connects: 4,
+			},
+		},
+		"ResetOnSpecChange": {
+			reason: "The breaker should close when the managed resource's spec changes.",
+			calls:  []call{{1, 0, nil, errBoom}, {1, 0, nil, errBoom}, {1, 0, nil, errBoom}, {2, time.Second, nil, errBoom}},
+			want: want{
+				errs:     []error{errBoom, errBoom, errBoom, errBoom},
+				connects: 4,
+			},
+		},
+		"HalfOpenAfterCooldown": {
+			reason: "The breaker should allow one call after the cooldown, and open for twice as long if it fails.",
+			calls:  []call{{1, 0, nil, errBoom}, {1, 0, nil, errBoom}, {1, 0, nil, errBoom}, {1, time.Minute, nil, errBoom}, {1, 2 * time.Minute, nil, errBoom}},
+			want: want{
+				errs:     []error{errBoom, errBoom, errBoom, errBoom, open(4, start.Add(3*time.Minute))},
+				connects: 4,
+			},
+		},
+		"SuccessCloses": {
+			reason: "A successful reconcile should reset the count of consecutive failures.",
+			calls:  []call{{1, 0, nil, errBoom}, {1, 0, nil, errBoom}, {1, 0, nil, nil}, {1, 0, nil, errBoom}, {1, 0, nil, errBoom}, {1, 0, nil, nil}},
+			want: want{
+				errs:     []error{errBoom, errBoom, nil, errBoom, errBoom, nil},
+				connects: 6,
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			now := start
+			b := New(WithThreshold(3), WithCooldown(time.Minute, 10*time.Minute), WithClock(func() time.Time { return now }))
+
+			connects := 0
+			var next call
+			c := NewConnecter(managed.ExternalConnectorFn(func(_ context.Context, _ resource.Managed) (managed.ExternalClient, error) {
+				connects++
+				if next.connectErr != nil {
+					return nil, next.connectErr
+				}
+				return &managed.ExternalClientFns{
+					ObserveFn: func(_ context.Context, _ resource.Managed) (managed.ExternalObservation, error) {
+						if next.observeErr != nil {
+							return managed.ExternalObservation{}, next.observeErr
+						}
+						return managed.ExternalObservation{ResourceExists: true, ResourceUpToDate: true}, nil
+					},
+				}, nil
+			}), b)
+
+			errs := make([]error, 0, len(tc.calls))
+			for _, cl := range tc.calls {
+				now = start.Add(cl.elapsed)
+				next = cl
+				mg := &fake.Managed{ObjectMeta: metav1.ObjectMeta{UID: "cool", Generation: cl.generation}}
+
+				ec, err := c.Connect(context.Background(), mg)
+				if err == nil {
+					_, err = ec.Observe(context.Background(), mg)
+				}
+				errs = append(errs, err)
+			}
+
+			if diff := cmp.Diff(tc.want.errs, errs, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nc.Connect(...): -want errors, +got errors:\n%s\n", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.connects, connects); diff != "" {
+				t.Errorf("\n%s\nc.Connect(...): -want inner connects, +got inner connects:\n%s\n", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestCheck(t *testing.T) {
+	start := time.Date(2022, 3, 14, 0, 0, 0, 0, time.UTC)
+	mg := &fake.Managed{ObjectMeta: metav1.ObjectMeta{UID: "cool", Generation: 1}}
+
+	now := start
+	b := New(WithThreshold(1), WithCooldown(time.Minute, 10*time.Minute), WithClock(func() time.Time { return now }))
+	if err := b.Check(context.Background(), mg); err != nil {
+		t.Errorf("b.Check(...): want nil error before any failures, got %s", err)
+	}
+
+	b.Failed(mg)
+	now = start.Add(20 * time.Second)
+
+	want := &gate.Wait{
+		Reason:  ReasonOpen,
+		Message: fmt.Sprintf(errOpenFmt, 1, start.Add(time.Minute).Format(time.RFC3339)),
+		After:   40 * time.Second,
+	}
+	if diff := cmp.Diff(want, b.Check(context.Background(), mg)); diff != "" {
+		t.Errorf("b.Check(...): -want, +got:\n%s\n", diff)
+	}
+}
+
+func TestExternal(t *testing.T) {
+	errBoom := errors.New("boom")
+	mg := &fake.Managed{ObjectMeta: metav1.ObjectMeta{UID: "cool", Generation: 1}}
+
+	cases := map[string]struct {
+		reason string
+		inner  managed.ExternalClient
+		call   func(e managed.ExternalClient) error
+		want   int
+	}{
+		"ObserveError": {
+			reason: "A failed Observe should be recorded as a failure.",
+			inner: &managed.ExternalClientFns{
+				ObserveFn: func(_ context.Context, _ resource.Managed) (managed.ExternalObservation, error) {
+					return managed.ExternalObservation{}, errBoom
+				},
+			},
+			call: func(e managed.ExternalClient) error {
+				_, err := e.Observe(context.Background(), mg)
+				return err
+			},
+			want: 2,
+		},
+		"ObserveNeedsUpdate": {
+			reason: "An Observe that finds the resource needs updating does not end the reconcile, so should not be recorded.",
+			inner: &managed.ExternalClientFns{
+				ObserveFn: func(_ context.Context, _ resource.Managed) (managed.ExternalObservation, error) {
+					return managed.ExternalObservation{ResourceExists: true}, nil
+				},
+			},
+			call: func(e managed.ExternalClient) error {
+				_, err := e.Observe(context.Background(), mg)
+				return err
+			},
+			want: 1,
+		},
+		"CreateError": {
+			reason: "A failed Create should be recorded as a failure.",
+			inner: &managed.ExternalClientFns{
+				CreateFn: func(_ context.Context, _ resource.Managed) (managed.ExternalCreation, error) {
+					return managed.ExternalCreation{}, errBoom
+				},
+			},
+			call: func(e managed.ExternalClient) error {
+				_, err := e.Create(context.Background(), mg)
+				return err
+			},
+			want: 2,
+		},
+		"UpdateSuccess": {
+			reason: "A successful Update should be recorded as a success.",
+			inner: &managed.ExternalClientFns{
+				UpdateFn: func(_ context.Context, _ resource.Managed) (managed.ExternalUpdate, error) {
+					return managed.ExternalUpdate{}, nil
+				},
+			},
+			call: func(e managed.ExternalClient) error {
+				_, err := e.Update(context.Background(), mg)
+				return err
+			},
+			want: 0,
+		},
+		"DeleteError": {
+			reason: "A failed Delete should be recorded as a failure.",
+			inner: &managed.ExternalClientFns{
+				DeleteFn: func(_ context.Context, _ resource.Managed) error {
+					return errBoom
+				},
+			},
+			call: func(e managed.ExternalClient) error {
+				return e.Delete(context.Background(), mg)
+			},
+			want: 2,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			b := New()
+
+			// Start with one failure recorded.
+			b.Failed(mg)
+
+			_ = tc.call(&external{inner: tc.inner, breaker: b})
+
+			if diff := cmp.Diff(tc.want, b.failures[key(mg)].count); diff != "" {
+				t.Errorf("\n%s\n-want consecutive failures, +got consecutive failures:\n%s\n", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestForget(t *testing.T) {
+	failed := &fake.Managed{ObjectMeta: metav1.ObjectMeta{Name: "cool", UID: "cool-1", Generation: 1}}
+
+	cases := map[string]struct {
+		reason string
+		forget func(b *Breaker)
+		mg     resource.Managed
+		open   bool
+	}{
+		"NotForgotten": {
+			reason: "The breaker should stay open for a managed resource that was not forgotten.",
+			forget: func(_ *Breaker) {},
+			mg:     failed,
+			open:   true,
+		},
+		"Forgotten": {
+			reason: "The breaker should close for a managed resource that was forgotten.",
+			forget: func(b *Breaker) { b.Forget(types.NamespacedName{Name: "cool"}) },
+			mg:     failed,
+			open:   false,
+		},
+		"Recreated": {
+			reason: "The breaker should close for a managed resource that was deleted and recreated with the same name.",
+			forget: func(_ *Breaker) {},
+			mg:     &fake.Managed{ObjectMeta: metav1.ObjectMeta{Name: "cool", UID: "cool-2", Generation: 1}},
+			open:   false,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			b := New(WithThreshold(1))
+			b.Failed(failed)
+			tc.forget(b)
+
+			if got := b.Check(context.Background(), tc.mg) != nil; got != tc.open {
+				t.Errorf("\n%s\nb.Check(...): want open %t, got open %t", tc.reason, tc.open, got)
+			}
+			if _, ok := b.failures[key(tc.mg)]; ok && !tc.open {
+				t.Errorf("\n%s\nb.Check(...): want failures dropped", tc.reason)
+			}
+		})
+	}
+}
@@ -0,0 +1,166 @@
+/*
+Copyright 2022 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package gate holds back managed resources that cannot be reconciled yet,
+// reporting why rather than letting each reconcile fail.
+package gate
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	xpv1 "github.com/crossplane/crossplane-runtime/apis/common/v1"
+	"github.com/crossplane/crossplane-runtime/pkg/meta"
+	"github.com/crossplane/crossplane-runtime/pkg/resource"
+)
+
+const (
+	errGetManaged    = "cannot get managed resource"
+	errUpdateManaged = "cannot update managed resource status"
+)
+
+// A Wait error indicates that a managed resource cannot be reconciled yet,
+// and that it should be tried again after a while.
+type Wait struct {
+	// Reason is the reason of the Synced condition reported while waiting.
+	Reason xpv1.ConditionReason
+
+	// Message explains what the managed resource is waiting for.
+	Message string
+
+	// After is how long to wait before trying again.
+	After time.Duration
+}
+
+func (w *Wait) Error() string {
+	return w.Message
+}
+
+// IsWait returns true if the supplied error is, or wraps, a Wait.
+func IsWait(err error) bool {
+	w := &Wait{}
+	return errors.As(err, &w)
+}
+
+// Condition returns a condition indicating that a managed resource is not
+// synced because it is waiting for the supplied reason.
+func Condition(w *Wait) xpv1.Condition {
+	return xpv1.Condition{
+		Type:               xpv1.TypeSynced,
+		Status:             corev1.ConditionFalse,
+		LastTransitionTime: metav1.Now(),
+		Reason:             w.Reason,
+		Message:            w.Message,
+	}
+}
+
+// A Check returns a Wait if the supplied managed resource cannot be
+// reconciled yet. Any other error is ignored; the inner Reconciler will
+// surface it in its usual way.
+type Check func(ctx context.Context, mg resource.Managed) error
+
+// A Forget func forgets anything it knows about the named managed resource.
+// It is called when the managed resource no longer exists or is being
+// deleted.
+type Forget func(nn types.NamespacedName)
+
+// A Reconciler only calls an inner, wrapped Reconciler for managed resources
+// that pass all of its Checks.
+type Reconciler struct {
+	client client.Client
+	of     resource.ManagedKind
+	inner  reconcile.Reconciler
+	checks []Check
+	forget []Forget
+}
+
+// A ReconcilerOption configures a Reconciler.
+type ReconcilerOption func(*Reconciler)
+
+// WithChecks configures the Checks a managed resource must pass before it is
+// reconciled.
+func WithChecks(c ...Check) ReconcilerOption {
+	return func(r *Reconciler) {
+		r.checks = append(r.checks, c...)
+	}
+}
+
+// WithForget configures functions that are called when a managed resource no
+// longer exists or is being deleted.
+func WithForget(f ...Forget) ReconcilerOption {
+	return func(r *Reconciler) {
+		r.forget = append(r.forget, f...)
+	}
+}
+
+// NewReconciler wraps the supplied Reconciler, which must reconcile managed
+// resources of the supplied kind.
+func NewReconciler(c client.Client, of resource.ManagedKind, r reconcile.Reconciler, o ...ReconcilerOption) *Reconciler {
+	gr := &Reconciler{client: c, of: of, inner: r}
+	for _, ro := range o {
+		ro(gr)
+	}
+	return gr
+}
+
+// Reconcile the supplied request, unless a Check says the managed resource
+// must wait. In that case we set the managed resource's Synced condition to
+// explain why and requeue it after the wait, without calling the inner
+// Reconciler. Managed resources that are being deleted are never held back;
+// the inner Reconciler may be able to delete them without anything they'd
+// wait for, for example by orphaning their external resource.
+func (r *Reconciler) Reconcile(ctx context.Context, req reconcile.Request) (reconcile.Result, error) {
+	mg := resource.MustCreateObject(schema.GroupVersionKind(r.of), r.client.Scheme()).(resource.Managed)
+	if err := r.client.Get(ctx, req.NamespacedName, mg); err != nil {
+		// The inner Reconciler knows what to do when the managed resource
+		// doesn't exist.
+		if kerr := resource.IgnoreNotFound(err); kerr != nil {
+			return reconcile.Result{}, errors.Wrap(kerr, errGetManaged)
+		}
+		r.doForget(req.NamespacedName)
+		return r.inner.Reconcile(ctx, req)
+	}
+
+	if meta.WasDeleted(mg) {
+		r.doForget(req.NamespacedName)
+		return r.inner.Reconcile(ctx, req)
+	}
+
+	for _, check := range r.checks {
+		w := &Wait{}
+		if !errors.As(check(ctx, mg), &w) {
+			continue
+		}
+		mg.SetConditions(Condition(w))
+		return reconcile.Result{RequeueAfter: w.After}, errors.Wrap(resource.IgnoreNotFound(r.client.Status().Update(ctx, mg)), errUpdateManaged)
+	}
+
+	return r.inner.Reconcile(ctx, req)
+}
+
+func (r *Reconciler) doForget(nn types.NamespacedName) {
+	for _, fn := range r.forget {
+		fn(nn)
+	}
+}
@@ -0,0 +1,202 @@
+/*
+Copyright 2022 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gate
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/pkg/errors"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	xpv1 "github.com/crossplane/crossplane-runtime/apis/common/v1"
+	"github.com/crossplane/crossplane-runtime/pkg/resource"
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+
+	"github.com/crossplane/provider-template/apis/sample/v1alpha1"
+)
+
+func TestIsWait(t *testing.T) {
+	cases := map[string]struct {
+		err  error
+		want bool
+	}{
+		"Wait":        {err: &Wait{}, want: true},
+		"WrappedWait": {err: errors.Wrap(&Wait{}, "waiting"), want: true},
+		"OtherError":  {err: errors.New("boom"), want: false},
+		"NoError":     {err: nil, want: false},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			if got := IsWait(tc.err); got != tc.want {
+				t.Errorf("IsWait(%v): want %t, got %t", tc.err, tc.want, got)
+			}
+		})
+	}
+}
+
+func TestReconcile(t *testing.T) {
+	errBoom := errors.New("boom")
+	wait := &Wait{Reason: "Waiting", Message: "waiting for something", After: 10 * time.Second}
+
+	s := runtime.NewScheme()
+	if err := v1alpha1.SchemeBuilder.AddToScheme(s); err != nil {
+		t.Fatalf("cannot build scheme: %s", err)
+	}
+
+	pass := func(_ context.Context, _ resource.Managed) error { return nil }
+	hold := func(_ context.Context, _ resource.Managed) error { return wait }
+	inner := reconcile.Func(func(_ context.Context, _ reconcile.Request) (reconcile.Result, error) {
+		return reconcile.Result{RequeueAfter: time.Minute}, nil
+	})
+
+	type args struct {
+		kube   client.Client
+		checks []Check
+	}
+
+	type want struct {
+		result reconcile.Result
+		err    error
+		forgot bool
+	}
+
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   want
+	}{
+		"AllChecksPass": {
+			reason: "We should call the inner reconciler if all checks pass.",
+			args: args{
+				kube: &test.MockClient{
+					MockGet:    test.NewMockGetFn(nil),
+					MockScheme: test.NewMockSchemeFn(s),
+				},
+				checks: []Check{pass, pass},
+			},
+			want: want{result: reconcile.Result{RequeueAfter: time.Minute}},
+		},
+		"CheckErrorIgnored": {
+			reason: "We should call the inner reconciler if a check returns an error that is not a Wait.",
+			args: args{
+				kube: &test.MockClient{
+					MockGet:    test.NewMockGetFn(nil),
+					MockScheme: test.NewMockSchemeFn(s),
+				},
+				checks: []Check{func(_ context.Context, _ resource.Managed) error { return errBoom }},
+			},
+			want: want{result: reconcile.Result{RequeueAfter: time.Minute}},
+		},
+		"CheckWaits": {
+			reason: "We should report why the managed resource is waiting and requeue it after the wait.",
+			args: args{
+				kube: &test.MockClient{
+					MockGet:    test.NewMockGetFn(nil),
+					MockScheme: test.NewMockSchemeFn(s),
+					MockStatusUpdate: func(_ context.Context, obj client.Object, _ ...client.UpdateOption) error {
+						got := obj.(resource.Managed).GetCondition(Condition(wait).Type)
+						if !got.Equal(Condition(wait)) {
+							t.Errorf("kube.Status().Update(...): want %+v, got %+v", Condition(wait), got)
+						}
+						return nil
+					},
+				},
+				checks: []Check{pass, hold},
+			},
+			want: want{result: reconcile.Result{RequeueAfter: wait.After}},
+		},
+		"UpdateStatusError": {
+			reason: "We should return any error encountered while reporting why the managed resource is waiting.",
+			args: args{
+				kube: &test.MockClient{
+					MockGet:          test.NewMockGetFn(nil),
+					MockScheme:       test.NewMockSchemeFn(s),
+					MockStatusUpdate: test.NewMockStatusUpdateFn(errBoom),
+				},
+				checks: []Check{hold},
+			},
+			want: want{result: reconcile.Result{RequeueAfter: wait.After}, err: errors.Wrap(errBoom, errUpdateManaged)},
+		},
+		"OrphanDeletedWithoutCredentials": {
+			reason: "We should not hold back a managed resource that is being deleted, for example one whose credentials are gone but which orphans its external resource.",
+			args: args{
+				kube: &test.MockClient{
+					MockGet: test.NewMockGetFn(nil, func(obj client.Object) error {
+						now := metav1.Now()
+						obj.SetDeletionTimestamp(&now)
+						obj.(resource.Managed).SetDeletionPolicy(xpv1.DeletionOrphan)
+						return nil
+					}),
+					MockScheme: test.NewMockSchemeFn(s),
+				},
+				checks: []Check{hold},
+			},
+			want: want{result: reconcile.Result{RequeueAfter: time.Minute}, forgot: true},
+		},
+		"ManagedNotFound": {
+			reason: "We should forget a managed resource that doesn't exist, and let the inner reconciler handle it.",
+			args: args{
+				kube: &test.MockClient{
+					MockGet:    test.NewMockGetFn(kerrors.NewNotFound(schema.GroupResource{}, "cool")),
+					MockScheme: test.NewMockSchemeFn(s),
+				},
+				checks: []Check{hold},
+			},
+			want: want{result: reconcile.Result{RequeueAfter: time.Minute}, forgot: true},
+		},
+		"GetManagedError": {
+			reason: "We should return any error encountered while getting the managed resource.",
+			args: args{
+				kube: &test.MockClient{
+					MockGet:    test.NewMockGetFn(errBoom),
+					MockScheme: test.NewMockSchemeFn(s),
+				},
+				checks: []Check{hold},
+			},
+			want: want{err: errors.Wrap(errBoom, errGetManaged)},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			forgot := false
+			r := NewReconciler(tc.args.kube, resource.ManagedKind(v1alpha1.MyTypeGroupVersionKind), inner,
+				WithChecks(tc.args.checks...),
+				WithForget(func(_ types.NamespacedName) { forgot = true }))
+			got, err := r.Reconcile(context.Background(), reconcile.Request{})
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nr.Reconcile(...): -want error, +got error:\n%s\n", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.result, got); diff != "" {
+				t.Errorf("\n%s\nr.Reconcile(...): -want, +got:\n%s\n", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.forgot, forgot); diff != "" {
+				t.Errorf("\n%s\nr.Reconcile(...): -want forgotten, +got forgotten:\n%s\n", tc.reason, diff)
+			}
+		})
+	}
+}
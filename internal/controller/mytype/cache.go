@@ -0,0 +1,62 @@
+/*
+Copyright 2022 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mytype
+
+import (
+	"crypto/sha256"
+	"sync"
+)
+
+// A serviceCache reuses the service created for each ProviderConfig across
+// reconciles, along with any connections it pools. It creates a new service
+// when a ProviderConfig's credentials change.
+type serviceCache struct {
+	newServiceFn func(creds []byte) (interface{}, error)
+
+	mu       sync.Mutex
+	services map[string]cachedService
+}
+
+type cachedService struct {
+	credsHash [sha256.Size]byte
+	service   interface{}
+}
+
+func newServiceCache(fn func(creds []byte) (interface{}, error)) *serviceCache {
+	return &serviceCache{newServiceFn: fn, services: make(map[string]cachedService)}
+}
+
+// Get the service for the named ProviderConfig, creating it if there is no
+// service cached for the supplied credentials. The cache holds at most one
+// service per ProviderConfig.
+func (c *serviceCache) Get(pc string, creds []byte) (interface{}, error) {
+	h := sha256.Sum256(creds)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if cs, ok := c.services[pc]; ok && cs.credsHash == h {
+		return cs.service, nil
+	}
+
+	svc, err := c.newServiceFn(creds)
+	if err != nil {
+		return nil, err
+	}
+	c.services[pc] = cachedService{credsHash: h, service: svc}
+	return svc, nil
+}
@@ -0,0 +1,111 @@
+/*
+Copyright 2022 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mytype
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/pkg/errors"
+
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+)
+
+type fakeService struct {
+	creds string
+}
+
+func TestServiceCacheGet(t *testing.T) {
+	errBoom := errors.New("boom")
+
+	type get struct {
+		pc    string
+		creds string
+	}
+
+	type want struct {
+		// same is true if each Get after the first should return the same
+		// service as the first.
+		same    []bool
+		created int
+		err     error
+	}
+
+	cases := map[string]struct {
+		reason string
+		fn     func(creds []byte) (interface{}, error)
+		gets   []get
+		want   want
+	}{
+		"SameCredentials": {
+			reason: "We should reuse the service for a ProviderConfig whose credentials have not changed.",
+			gets:   []get{{pc: "cool", creds: "a"}, {pc: "cool", creds: "a"}},
+			want:   want{same: []bool{true}, created: 1},
+		},
+		"ChangedCredentials": {
+			reason: "We should create a new service for a ProviderConfig whose credentials have changed.",
+			gets:   []get{{pc: "cool", creds: "a"}, {pc: "cool", creds: "b"}, {pc: "cool", creds: "b"}},
+			want:   want{same: []bool{false, false}, created: 2},
+		},
+		"DifferentProviderConfigs": {
+			reason: "We should not share services between ProviderConfigs.",
+			gets:   []get{{pc: "cool", creds: "a"}, {pc: "lame", creds: "a"}},
+			want:   want{same: []bool{false}, created: 2},
+		},
+		"NewServiceError": {
+			reason: "We should return any error encountered creating a service.",
+			fn:     func(_ []byte) (interface{}, error) { return nil, errBoom },
+			gets:   []get{{pc: "cool", creds: "a"}},
+			want:   want{same: []bool{}, err: errBoom},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			created := 0
+			fn := func(creds []byte) (interface{}, error) {
+				created++
+				return &fakeService{creds: string(creds)}, nil
+			}
+			if tc.fn != nil {
+				fn = tc.fn
+			}
+			c := newServiceCache(fn)
+
+			var first interface{}
+			same := []bool{}
+			for i, g := range tc.gets {
+				svc, err := c.Get(g.pc, []byte(g.creds))
+				if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+					t.Errorf("\n%s\nc.Get(...): -want error, +got error:\n%s\n", tc.reason, diff)
+				}
+				if i == 0 {
+					first = svc
+					continue
+				}
+				same = append(same, svc == first)
+			}
+
+			if diff := cmp.Diff(tc.want.same, same); diff != "" {
+				t.Errorf("\n%s\nc.Get(...): -want same service, +got same service:\n%s\n", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.created, created); diff != "" {
+				t.Errorf("\n%s\nc.Get(...): -want services created, +got services created:\n%s\n", tc.reason, diff)
+			}
+		})
+	}
+}
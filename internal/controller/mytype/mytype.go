@@ -19,22 +19,30 @@ package mytype
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/pkg/errors"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
+	xpv1 "github.com/crossplane/crossplane-runtime/apis/common/v1"
 	"github.com/crossplane/crossplane-runtime/pkg/connection"
 	"github.com/crossplane/crossplane-runtime/pkg/controller"
 	"github.com/crossplane/crossplane-runtime/pkg/event"
+	"github.com/crossplane/crossplane-runtime/pkg/logging"
 	"github.com/crossplane/crossplane-runtime/pkg/ratelimiter"
 	"github.com/crossplane/crossplane-runtime/pkg/reconciler/managed"
 	"github.com/crossplane/crossplane-runtime/pkg/resource"
 
 	"github.com/crossplane/provider-template/apis/sample/v1alpha1"
 	apisv1alpha1 "github.com/crossplane/provider-template/apis/v1alpha1"
+	"github.com/crossplane/provider-template/internal/controller/breaker"
 	"github.com/crossplane/provider-template/internal/controller/features"
+	"github.com/crossplane/provider-template/internal/controller/gate"
+	"github.com/crossplane/provider-template/internal/controller/poll"
 )
 
 const (
@@ -42,10 +50,26 @@ const (
 	errTrackPCUsage = "cannot track ProviderConfig usage"
 	errGetPC        = "cannot get ProviderConfig"
 	errGetCreds     = "cannot get credentials"
+	errUpdatePC     = "cannot update ProviderConfig status"
+	errPCNotReady   = "ProviderConfig is not ready"
+	errNoCredsFmt   = "credentials secret %s/%s has no data at key %q"
 
 	errNewClient = "cannot create new Service"
 )
 
+// reasonPCNotReady indicates that a managed resource is waiting for its
+// ProviderConfig's credentials to be available.
+const reasonPCNotReady xpv1.ConditionReason = "ProviderConfigNotReady"
+
+// pcNotReadyWait is how long a managed resource waits before checking again
+// whether its ProviderConfig's credentials are available.
+const pcNotReadyWait = 10 * time.Second
+
+// credentialsUsedRefresh is how old a ProviderConfig's LastCredentialsUseTime
+// may be before a successful Connect refreshes it. This bounds the rate at
+// which busy controllers update the ProviderConfig's status.
+const credentialsUsedRefresh = 5 * time.Minute
+
 // A NoOpService does nothing.
 type NoOpService struct{}
 
@@ -62,36 +86,56 @@ func Setup(mgr ctrl.Manager, o controller.Options) error {
 		cps = append(cps, connection.NewDetailsManager(mgr.GetClient(), apisv1alpha1.StoreConfigGroupVersionKind))
 	}
 
+	// Stop calling the external API on behalf of MyTypes that repeatedly
+	// fail to reconcile.
+	b := breaker.New()
+	conn := &connector{
+		log:          o.Logger.WithValues("controller", name),
+		kube:         mgr.GetClient(),
+		usage:        resource.NewProviderConfigUsageTracker(mgr.GetClient(), &apisv1alpha1.ProviderConfigUsage{}),
+		newServiceFn: newServiceCache(newNoOpService).Get}
+	c := breaker.NewConnecter(conn, b)
+
 	r := managed.NewReconciler(mgr,
 		resource.ManagedKind(v1alpha1.MyTypeGroupVersionKind),
-		managed.WithExternalConnecter(&connector{
-			kube:         mgr.GetClient(),
-			usage:        resource.NewProviderConfigUsageTracker(mgr.GetClient(), &apisv1alpha1.ProviderConfigUsage{}),
-			newServiceFn: newNoOpService}),
+		managed.WithExternalConnecter(c),
+		managed.WithPollInterval(o.PollInterval),
 		managed.WithLogger(o.Logger.WithValues("controller", name)),
 		managed.WithRecorder(event.NewAPIRecorder(mgr.GetEventRecorderFor(name))),
 		managed.WithConnectionPublishers(cps...))
 
+	// Let individual MyTypes override the poll interval, and record the
+	// generation of each that we successfully reconcile.
+	pr := poll.NewReconciler(mgr.GetClient(), resource.ManagedKind(v1alpha1.MyTypeGroupVersionKind), r, o.PollInterval)
+
+	// Report that a MyType is waiting for its ProviderConfig's credentials,
+	// or for its breaker to close, rather than failing to reconcile it. The
+	// breaker forgets MyTypes that are deleted.
+	gr := gate.NewReconciler(mgr.GetClient(), resource.ManagedKind(v1alpha1.MyTypeGroupVersionKind), pr,
+		gate.WithChecks(conn.checkCredentials, b.Check),
+		gate.WithForget(b.Forget))
+
 	return ctrl.NewControllerManagedBy(mgr).
 		Named(name).
 		WithOptions(o.ForControllerRuntime()).
 		For(&v1alpha1.MyType{}).
-		Complete(ratelimiter.NewReconciler(name, r, o.GlobalRateLimiter))
+		Complete(ratelimiter.NewReconciler(name, gr, o.GlobalRateLimiter))
 }
 
 // A connector is expected to produce an ExternalClient when its Connect method
 // is called.
 type connector struct {
+	log          logging.Logger
 	kube         client.Client
 	usage        resource.Tracker
-	newServiceFn func(creds []byte) (interface{}, error)
+	newServiceFn func(pc string, creds []byte) (interface{}, error)
 }
 
 // Connect typically produces an ExternalClient by:
 // 1. Tracking that the managed resource is using a ProviderConfig.
 // 2. Getting the managed resource's ProviderConfig.
 // 3. Getting the credentials specified by the ProviderConfig.
-// 4. Using the credentials to form a client.
+// 4. Using the credentials to form a client, or reusing one formed earlier.
 func (c *connector) Connect(ctx context.Context, mg resource.Managed) (managed.ExternalClient, error) {
 	cr, ok := mg.(*v1alpha1.MyType)
 	if !ok {
@@ -107,20 +151,83 @@ func (c *connector) Connect(ctx context.Context, mg resource.Managed) (managed.E
 		return nil, errors.Wrap(err, errGetPC)
 	}
 
-	cd := pc.Spec.Credentials
-	data, err := resource.CommonCredentialExtractor(ctx, cd.Source, c.kube, cd.CommonCredentialSelectors)
+	data, err := c.credentials(ctx, pc)
 	if err != nil {
-		return nil, errors.Wrap(err, errGetCreds)
+		return nil, err
 	}
 
-	svc, err := c.newServiceFn(data)
+	svc, err := c.newServiceFn(pc.GetName(), data)
 	if err != nil {
 		return nil, errors.Wrap(err, errNewClient)
 	}
 
+	// Recording that the credentials were used is informational, so it
+	// shouldn't stop us reconciling the managed resource.
+	if err := c.markCredentialsUsed(ctx, pc); err != nil {
+		c.log.Info(errUpdatePC, "name", pc.GetName(), "error", err)
+	}
+
 	return &external{service: svc}, nil
 }
 
+// credentials returns the supplied ProviderConfig's credentials. It returns a
+// gate.Wait if they are not available yet.
+func (c *connector) credentials(ctx context.Context, pc *apisv1alpha1.ProviderConfig) ([]byte, error) {
+	cd := pc.Spec.Credentials
+	data, err := resource.CommonCredentialExtractor(ctx, cd.Source, c.kube, cd.CommonCredentialSelectors)
+	if kerrors.IsNotFound(err) {
+		// The credentials secret may not have been created yet, for example
+		// by another controller. We'll try again.
+		return nil, notReady(err)
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, errGetCreds)
+	}
+	if cd.Source == xpv1.CredentialsSourceSecret && len(data) == 0 {
+		ref := cd.SecretRef
+		return nil, notReady(errors.Errorf(errNoCredsFmt, ref.Namespace, ref.Name, ref.Key))
+	}
+	return data, nil
+}
+
+// checkCredentials is a gate.Check that returns a gate.Wait if the supplied
+// managed resource's ProviderConfig's credentials are not available yet.
+func (c *connector) checkCredentials(ctx context.Context, mg resource.Managed) error {
+	ref := mg.GetProviderConfigReference()
+	if ref == nil {
+		return nil
+	}
+	pc := &apisv1alpha1.ProviderConfig{}
+	if err := c.kube.Get(ctx, types.NamespacedName{Name: ref.Name}, pc); err != nil {
+		return errors.Wrap(err, errGetPC)
+	}
+	_, err := c.credentials(ctx, pc)
+	return err
+}
+
+func notReady(err error) error {
+	return &gate.Wait{
+		Reason:  reasonPCNotReady,
+		Message: errors.Wrap(err, errPCNotReady).Error(),
+		After:   pcNotReadyWait,
+	}
+}
+
+// markCredentialsUsed records that the supplied ProviderConfig's credentials
+// were just used successfully, unless that was recorded recently. Conflicts
+// are ignored; another Connect will soon record the same thing.
+func (c *connector) markCredentialsUsed(ctx context.Context, pc *apisv1alpha1.ProviderConfig) error {
+	if t := pc.Status.LastCredentialsUseTime; t != nil && time.Since(t.Time) < credentialsUsedRefresh {
+		return nil
+	}
+
+	now := metav1.Now()
+	pc.Status.LastCredentialsUseTime = &now
+	pc.Status.SetConditions(apisv1alpha1.CredentialsUsed())
+
+	return resource.Ignore(kerrors.IsConflict, c.kube.Status().Update(ctx, pc))
+}
+
 // An ExternalClient observes, then either creates, updates, or deletes an
 // external resource to ensure it reflects the managed resource's desired state.
 type external struct {
@@ -19,12 +19,26 @@ package mytype
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 
+	xpv1 "github.com/crossplane/crossplane-runtime/apis/common/v1"
+	"github.com/crossplane/crossplane-runtime/pkg/logging"
 	"github.com/crossplane/crossplane-runtime/pkg/reconciler/managed"
 	"github.com/crossplane/crossplane-runtime/pkg/resource"
 	"github.com/crossplane/crossplane-runtime/pkg/test"
+
+	"github.com/crossplane/provider-template/apis/sample/v1alpha1"
+	apisv1alpha1 "github.com/crossplane/provider-template/apis/v1alpha1"
+	"github.com/crossplane/provider-template/internal/controller/breaker"
+	"github.com/crossplane/provider-template/internal/controller/gate"
 )
 
 // Unlike many Kubernetes projects Crossplane does not use third party testing
@@ -72,3 +86,279 @@ func TestObserve(t *testing.T) {
 		})
 	}
 }
+
+// secretPC makes the supplied object, if it is a ProviderConfig, load its
+// credentials from a secret.
+func secretPC(obj client.Object) error {
+	if pc, ok := obj.(*apisv1alpha1.ProviderConfig); ok {
+		pc.Spec.Credentials.Source = xpv1.CredentialsSourceSecret
+		pc.Spec.Credentials.SecretRef = &xpv1.SecretKeySelector{
+			SecretReference: xpv1.SecretReference{Namespace: "crossplane-system", Name: "creds"},
+			Key:             "credentials",
+		}
+	}
+	return nil
+}
+
+func TestConnect(t *testing.T) {
+	errBoom := errors.New("boom")
+	errNotFound := kerrors.NewNotFound(schema.GroupResource{Resource: "secrets"}, "creds")
+
+	mt := &v1alpha1.MyType{
+		Spec: v1alpha1.MyTypeSpec{
+			ResourceSpec: xpv1.ResourceSpec{
+				ProviderConfigReference: &xpv1.Reference{Name: "cool"},
+			},
+		},
+	}
+
+	// withPC returns a MockGetFn that returns a ProviderConfig whose
+	// credentials were last used at the supplied time, if any.
+	withPC := func(used *metav1.Time) test.MockGetFn {
+		return test.NewMockGetFn(nil, func(obj client.Object) error {
+			pc := obj.(*apisv1alpha1.ProviderConfig)
+			pc.Spec.Credentials.Source = xpv1.CredentialsSourceNone
+			if used != nil {
+				pc.Status.SetConditions(apisv1alpha1.CredentialsUsed())
+				pc.Status.LastCredentialsUseTime = used
+			}
+			return nil
+		})
+	}
+
+	recently := metav1.Now()
+	stale := metav1.NewTime(time.Now().Add(-2 * credentialsUsedRefresh))
+
+	type fields struct {
+		kube client.Client
+	}
+
+	type args struct {
+		ctx context.Context
+		mg  resource.Managed
+	}
+
+	type want struct {
+		err error
+	}
+
+	cases := map[string]struct {
+		reason string
+		fields fields
+		args   args
+		want   want
+	}{
+		"MarkCredentialsUsed": {
+			reason: "A successful Connect should mark the ProviderConfig's credentials as used.",
+			fields: fields{
+				kube: &test.MockClient{
+					MockGet: withPC(nil),
+					MockStatusUpdate: func(_ context.Context, obj client.Object, _ ...client.UpdateOption) error {
+						pc := obj.(*apisv1alpha1.ProviderConfig)
+						if got := pc.Status.GetCondition(apisv1alpha1.TypeCredentialsUsed); !got.Equal(apisv1alpha1.CredentialsUsed()) {
+							t.Errorf("kube.Status().Update(...): want %s condition, got %+v", apisv1alpha1.TypeCredentialsUsed, got)
+						}
+						if pc.Status.LastCredentialsUseTime == nil {
+							t.Errorf("kube.Status().Update(...): want lastCredentialsUseTime, got nil")
+						}
+						return nil
+					},
+				},
+			},
+			args: args{ctx: context.Background(), mg: mt},
+			want: want{},
+		},
+		"RefreshStaleCredentialsUsed": {
+			reason: "A successful Connect should refresh a stale lastCredentialsUseTime.",
+			fields: fields{
+				kube: &test.MockClient{
+					MockGet: withPC(&stale),
+					MockStatusUpdate: func(_ context.Context, obj client.Object, _ ...client.UpdateOption) error {
+						got := obj.(*apisv1alpha1.ProviderConfig).Status.LastCredentialsUseTime
+						if got == nil || time.Since(got.Time) > time.Minute {
+							t.Errorf("kube.Status().Update(...): want refreshed lastCredentialsUseTime, got %v", got)
+						}
+						return nil
+					},
+				},
+			},
+			args: args{ctx: context.Background(), mg: mt},
+			want: want{},
+		},
+		"CredentialsRecentlyUsed": {
+			reason: "We should not update the ProviderConfig if its credentials were recently marked as used.",
+			fields: fields{
+				kube: &test.MockClient{
+					MockGet:          withPC(&recently),
+					MockStatusUpdate: test.NewMockStatusUpdateFn(errBoom),
+				},
+			},
+			args: args{ctx: context.Background(), mg: mt},
+			want: want{},
+		},
+		"IgnoreConflict": {
+			reason: "We should not return an error if another controller updated the ProviderConfig first.",
+			fields: fields{
+				kube: &test.MockClient{
+					MockGet:          withPC(nil),
+					MockStatusUpdate: test.NewMockStatusUpdateFn(kerrors.NewConflict(schema.GroupResource{}, "cool", errBoom)),
+				},
+			},
+			args: args{ctx: context.Background(), mg: mt},
+			want: want{},
+		},
+		"CredentialsSecretNotFound": {
+			reason: "We should report that the ProviderConfig is not ready if its credentials secret does not exist yet.",
+			fields: fields{
+				kube: &test.MockClient{
+					MockGet: func(_ context.Context, _ client.ObjectKey, obj client.Object) error {
+						if _, ok := obj.(*corev1.Secret); ok {
+							return errNotFound
+						}
+						return secretPC(obj)
+					},
+				},
+			},
+			args: args{ctx: context.Background(), mg: mt},
+			want: want{err: notReady(errors.Wrap(errNotFound, "cannot get credentials secret"))},
+		},
+		"CredentialsSecretEmpty": {
+			reason: "We should report that the ProviderConfig is not ready if its credentials secret has no credentials yet.",
+			fields: fields{
+				kube: &test.MockClient{
+					MockGet: test.NewMockGetFn(nil, secretPC),
+				},
+			},
+			args: args{ctx: context.Background(), mg: mt},
+			want: want{err: notReady(errors.Errorf(errNoCredsFmt, "crossplane-system", "creds", "credentials"))},
+		},
+		"UpdateProviderConfigError": {
+			reason: "We should still connect if we can't record that the ProviderConfig's credentials were used.",
+			fields: fields{
+				kube: &test.MockClient{
+					MockGet:          withPC(nil),
+					MockStatusUpdate: test.NewMockStatusUpdateFn(errBoom),
+				},
+			},
+			args: args{ctx: context.Background(), mg: mt},
+			want: want{},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			c := &connector{
+				log:          logging.NewNopLogger(),
+				kube:         tc.fields.kube,
+				usage:        resource.TrackerFn(func(_ context.Context, _ resource.Managed) error { return nil }),
+				newServiceFn: func(_ string, creds []byte) (interface{}, error) { return newNoOpService(creds) },
+			}
+			ec, err := c.Connect(tc.args.ctx, tc.args.mg)
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nc.Connect(...): -want error, +got error:\n%s\n", tc.reason, diff)
+			}
+			if err == nil && ec == nil {
+				t.Errorf("\n%s\nc.Connect(...): want an ExternalClient, got nil", tc.reason)
+			}
+		})
+	}
+}
+
+func TestCheckCredentials(t *testing.T) {
+	errBoom := errors.New("boom")
+	errNotFound := kerrors.NewNotFound(schema.GroupResource{Resource: "secrets"}, "creds")
+
+	mt := &v1alpha1.MyType{
+		Spec: v1alpha1.MyTypeSpec{
+			ResourceSpec: xpv1.ResourceSpec{
+				ProviderConfigReference: &xpv1.Reference{Name: "cool"},
+			},
+		},
+	}
+
+	cases := map[string]struct {
+		reason string
+		kube   client.Client
+		want   error
+	}{
+		"CredentialsAvailable": {
+			reason: "We should not wait if the ProviderConfig's credentials are available.",
+			kube: &test.MockClient{
+				MockGet: test.NewMockGetFn(nil, func(obj client.Object) error {
+					if s, ok := obj.(*corev1.Secret); ok {
+						s.Data = map[string][]byte{"credentials": []byte("secret")}
+					}
+					return secretPC(obj)
+				}),
+			},
+			want: nil,
+		},
+		"CredentialsSecretNotFound": {
+			reason: "We should wait if the ProviderConfig's credentials secret does not exist yet.",
+			kube: &test.MockClient{
+				MockGet: func(_ context.Context, _ client.ObjectKey, obj client.Object) error {
+					if _, ok := obj.(*corev1.Secret); ok {
+						return errNotFound
+					}
+					return secretPC(obj)
+				},
+			},
+			want: notReady(errors.Wrap(errNotFound, "cannot get credentials secret")),
+		},
+		"CredentialsSecretEmpty": {
+			reason: "We should wait if the ProviderConfig's credentials secret has no credentials yet.",
+			kube: &test.MockClient{
+				MockGet: test.NewMockGetFn(nil, secretPC),
+			},
+			want: notReady(errors.Errorf(errNoCredsFmt, "crossplane-system", "creds", "credentials")),
+		},
+		"GetProviderConfigError": {
+			reason: "Errors other than missing credentials should not be reported as waits.",
+			kube: &test.MockClient{
+				MockGet: test.NewMockGetFn(errBoom),
+			},
+			want: errors.Wrap(errBoom, errGetPC),
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			c := &connector{kube: tc.kube}
+			err := c.checkCredentials(context.Background(), mt)
+			if diff := cmp.Diff(tc.want, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nc.checkCredentials(...): -want error, +got error:\n%s\n", tc.reason, diff)
+			}
+			if diff := cmp.Diff(gate.IsWait(tc.want), gate.IsWait(err)); diff != "" {
+				t.Errorf("\n%s\ngate.IsWait(...): -want, +got:\n%s\n", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestConnectNotReadyDoesNotTripBreaker(t *testing.T) {
+	mt := &v1alpha1.MyType{
+		ObjectMeta: metav1.ObjectMeta{UID: "cool", Generation: 1},
+		Spec: v1alpha1.MyTypeSpec{
+			ResourceSpec: xpv1.ResourceSpec{
+				ProviderConfigReference: &xpv1.Reference{Name: "cool"},
+			},
+		},
+	}
+
+	b := breaker.New(breaker.WithThreshold(1))
+	c := breaker.NewConnecter(&connector{
+		log:          logging.NewNopLogger(),
+		kube:         &test.MockClient{MockGet: test.NewMockGetFn(nil, secretPC)},
+		usage:        resource.TrackerFn(func(_ context.Context, _ resource.Managed) error { return nil }),
+		newServiceFn: func(_ string, creds []byte) (interface{}, error) { return newNoOpService(creds) },
+	}, b)
+
+	for i := 0; i < 3; i++ {
+		if _, err := c.Connect(context.Background(), mt); !gate.IsWait(err) {
+			t.Fatalf("c.Connect(...): want a wait for the ProviderConfig, got %v", err)
+		}
+	}
+	if err := b.Check(context.Background(), mt); err != nil {
+		t.Errorf("b.Check(...): want the breaker to stay closed while the ProviderConfig is not ready, got %s", err)
+	}
+}
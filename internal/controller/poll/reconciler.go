@@ -0,0 +1,114 @@
+/*
+Copyright 2022 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package poll allows individual managed resources to override the interval
+// at which they are polled for drift, and records the generation of each that
+// was successfully reconciled.
+package poll
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/crossplane/crossplane-runtime/pkg/resource"
+)
+
+// AnnotationKeyPollInterval overrides the interval at which a managed
+// resource is polled for drift, e.g. "30s" or "1h". Values that are not a
+// positive Go duration are ignored. Values shorter than MinPollInterval are
+// treated as MinPollInterval, so that one managed resource can't use up the
+// rate limit it shares with the others.
+const AnnotationKeyPollInterval = "template.crossplane.io/poll-interval"
+
+// MinPollInterval is the shortest poll interval AnnotationKeyPollInterval may
+// set.
+const MinPollInterval = 10 * time.Second
+
+const errUpdateManaged = "cannot update managed resource status"
+
+// A GenerationObserver records the latest generation of its spec that was
+// successfully reconciled.
+type GenerationObserver interface {
+	GetObservedGeneration() int64
+	SetObservedGeneration(g int64)
+}
+
+// A Reconciler lets the managed resources reconciled by an inner, wrapped
+// Reconciler override its poll interval using AnnotationKeyPollInterval.
+type Reconciler struct {
+	client   client.Client
+	of       resource.ManagedKind
+	inner    reconcile.Reconciler
+	interval time.Duration
+}
+
+// NewReconciler wraps the supplied Reconciler, which must poll managed
+// resources of the supplied kind at the supplied interval.
+func NewReconciler(c client.Client, of resource.ManagedKind, r reconcile.Reconciler, interval time.Duration) *Reconciler {
+	return &Reconciler{client: c, of: of, inner: r, interval: interval}
+}
+
+// Reconcile the supplied request, requeuing it after the managed resource's
+// poll interval if it would otherwise be requeued after the default. If the
+// managed resource was successfully reconciled and is a GenerationObserver we
+// record the generation we reconciled.
+func (r *Reconciler) Reconcile(ctx context.Context, req reconcile.Request) (reconcile.Result, error) {
+	// The managed resource's spec may change while we reconcile it, so we
+	// note which generation we're about to reconcile.
+	var generation int64
+	if mg, err := r.get(ctx, req); err == nil {
+		generation = mg.GetGeneration()
+	}
+
+	result, err := r.inner.Reconcile(ctx, req)
+
+	// The inner Reconciler only requeues after its poll interval when the
+	// managed resource was successfully reconciled. We leave the waits it
+	// uses to recover from errors, or while it waits on something, alone.
+	if err != nil || result.RequeueAfter != r.interval {
+		return result, err
+	}
+
+	mg, err := r.get(ctx, req)
+	if err != nil {
+		// The managed resource was reconciled successfully, so there's
+		// nothing to gain by failing here. We'll poll at the default.
+		return result, nil
+	}
+
+	if d, err := time.ParseDuration(mg.GetAnnotations()[AnnotationKeyPollInterval]); err == nil && d > 0 {
+		if d < MinPollInterval {
+			d = MinPollInterval
+		}
+		result.RequeueAfter = d
+	}
+
+	if o, ok := mg.(GenerationObserver); ok && generation > o.GetObservedGeneration() {
+		o.SetObservedGeneration(generation)
+		return result, errors.Wrap(resource.IgnoreNotFound(r.client.Status().Update(ctx, mg)), errUpdateManaged)
+	}
+	return result, nil
+}
+
+func (r *Reconciler) get(ctx context.Context, req reconcile.Request) (resource.Managed, error) {
+	mg := resource.MustCreateObject(schema.GroupVersionKind(r.of), r.client.Scheme()).(resource.Managed)
+	return mg, r.client.Get(ctx, req.NamespacedName, mg)
+}
@@ -0,0 +1,303 @@
+/*
+Copyright 2022 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package poll
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	xpv1 "github.com/crossplane/crossplane-runtime/apis/common/v1"
+	"github.com/crossplane/crossplane-runtime/pkg/reconciler/managed"
+	"github.com/crossplane/crossplane-runtime/pkg/resource"
+	"github.com/crossplane/crossplane-runtime/pkg/resource/fake"
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+
+	"github.com/crossplane/provider-template/apis/sample/v1alpha1"
+)
+
+func TestReconcile(t *testing.T) {
+	errBoom := errors.New("boom")
+	interval := time.Minute
+
+	s := runtime.NewScheme()
+	if err := v1alpha1.SchemeBuilder.AddToScheme(s); err != nil {
+		t.Fatalf("cannot build scheme: %s", err)
+	}
+
+	withAnnotations := func(a map[string]string) test.MockGetFn {
+		return test.NewMockGetFn(nil, func(obj client.Object) error {
+			obj.SetAnnotations(a)
+			return nil
+		})
+	}
+
+	withGenerations := func(generation, observed int64) test.MockGetFn {
+		return test.NewMockGetFn(nil, func(obj client.Object) error {
+			mt := obj.(*v1alpha1.MyType)
+			mt.SetGeneration(generation)
+			mt.Status.ObservedGeneration = observed
+			return nil
+		})
+	}
+
+	type args struct {
+		kube  client.Client
+		inner reconcile.Reconciler
+	}
+
+	type want struct {
+		result reconcile.Result
+		err    error
+	}
+
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   want
+	}{
+		"CustomInterval": {
+			reason: "A successfully reconciled resource should be requeued after its annotated poll interval.",
+			args: args{
+				kube: &test.MockClient{
+					MockGet:    withAnnotations(map[string]string{AnnotationKeyPollInterval: "30s"}),
+					MockScheme: test.NewMockSchemeFn(s),
+				},
+				inner: reconcile.Func(func(_ context.Context, _ reconcile.Request) (reconcile.Result, error) {
+					return reconcile.Result{RequeueAfter: interval}, nil
+				}),
+			},
+			want: want{result: reconcile.Result{RequeueAfter: 30 * time.Second}},
+		},
+		"BelowMinimum": {
+			reason: "A resource whose annotated poll interval is below the minimum should be requeued after the minimum.",
+			args: args{
+				kube: &test.MockClient{
+					MockGet:    withAnnotations(map[string]string{AnnotationKeyPollInterval: "1ms"}),
+					MockScheme: test.NewMockSchemeFn(s),
+				},
+				inner: reconcile.Func(func(_ context.Context, _ reconcile.Request) (reconcile.Result, error) {
+					return reconcile.Result{RequeueAfter: interval}, nil
+				}),
+			},
+			want: want{result: reconcile.Result{RequeueAfter: MinPollInterval}},
+		},
+		"RecordObservedGeneration": {
+			reason: "We should record the generation of a successfully reconciled resource.",
+			args: args{
+				kube: &test.MockClient{
+					MockGet:    withGenerations(3, 2),
+					MockScheme: test.NewMockSchemeFn(s),
+					MockStatusUpdate: func(_ context.Context, obj client.Object, _ ...client.UpdateOption) error {
+						if got := obj.(*v1alpha1.MyType).Status.ObservedGeneration; got != 3 {
+							t.Errorf("kube.Status().Update(...): want observed generation 3, got %d", got)
+						}
+						return nil
+					},
+				},
+				inner: reconcile.Func(func(_ context.Context, _ reconcile.Request) (reconcile.Result, error) {
+					return reconcile.Result{RequeueAfter: interval}, nil
+				}),
+			},
+			want: want{result: reconcile.Result{RequeueAfter: interval}},
+		},
+		"ObservedGenerationUnchanged": {
+			reason: "We should not update the status of a resource whose generation was already observed.",
+			args: args{
+				kube: &test.MockClient{
+					MockGet:          withGenerations(3, 3),
+					MockScheme:       test.NewMockSchemeFn(s),
+					MockStatusUpdate: test.NewMockStatusUpdateFn(errBoom),
+				},
+				inner: reconcile.Func(func(_ context.Context, _ reconcile.Request) (reconcile.Result, error) {
+					return reconcile.Result{RequeueAfter: interval}, nil
+				}),
+			},
+			want: want{result: reconcile.Result{RequeueAfter: interval}},
+		},
+		"ReconcileFailedObservedGeneration": {
+			reason: "We should not record the generation of a resource that was not successfully reconciled.",
+			args: args{
+				kube: &test.MockClient{
+					MockGet:          withGenerations(3, 2),
+					MockScheme:       test.NewMockSchemeFn(s),
+					MockStatusUpdate: test.NewMockStatusUpdateFn(errBoom),
+				},
+				inner: reconcile.Func(func(_ context.Context, _ reconcile.Request) (reconcile.Result, error) {
+					return reconcile.Result{Requeue: true}, nil
+				}),
+			},
+			want: want{result: reconcile.Result{Requeue: true}},
+		},
+		"UpdateObservedGenerationError": {
+			reason: "We should return any error encountered while recording the observed generation.",
+			args: args{
+				kube: &test.MockClient{
+					MockGet:          withGenerations(3, 2),
+					MockScheme:       test.NewMockSchemeFn(s),
+					MockStatusUpdate: test.NewMockStatusUpdateFn(errBoom),
+				},
+				inner: reconcile.Func(func(_ context.Context, _ reconcile.Request) (reconcile.Result, error) {
+					return reconcile.Result{RequeueAfter: interval}, nil
+				}),
+			},
+			want: want{result: reconcile.Result{RequeueAfter: interval}, err: errors.Wrap(errBoom, errUpdateManaged)},
+		},
+		"NoAnnotation": {
+			reason: "A resource without the annotation should be requeued after the default poll interval.",
+			args: args{
+				kube: &test.MockClient{
+					MockGet:    withAnnotations(nil),
+					MockScheme: test.NewMockSchemeFn(s),
+				},
+				inner: reconcile.Func(func(_ context.Context, _ reconcile.Request) (reconcile.Result, error) {
+					return reconcile.Result{RequeueAfter: interval}, nil
+				}),
+			},
+			want: want{result: reconcile.Result{RequeueAfter: interval}},
+		},
+		"InvalidAnnotation": {
+			reason: "A resource with an invalid annotation should be requeued after the default poll interval.",
+			args: args{
+				kube: &test.MockClient{
+					MockGet:    withAnnotations(map[string]string{AnnotationKeyPollInterval: "soon"}),
+					MockScheme: test.NewMockSchemeFn(s),
+				},
+				inner: reconcile.Func(func(_ context.Context, _ reconcile.Request) (reconcile.Result, error) {
+					return reconcile.Result{RequeueAfter: interval}, nil
+				}),
+			},
+			want: want{result: reconcile.Result{RequeueAfter: interval}},
+		},
+		"GetManagedError": {
+			reason: "We should requeue after the default poll interval if we can't get the managed resource.",
+			args: args{
+				kube: &test.MockClient{
+					MockGet:    test.NewMockGetFn(errBoom),
+					MockScheme: test.NewMockSchemeFn(s),
+				},
+				inner: reconcile.Func(func(_ context.Context, _ reconcile.Request) (reconcile.Result, error) {
+					return reconcile.Result{RequeueAfter: interval}, nil
+				}),
+			},
+			want: want{result: reconcile.Result{RequeueAfter: interval}},
+		},
+		"OtherWait": {
+			reason: "We should not override a requeue that isn't a poll, for example a wait after an error.",
+			args: args{
+				kube: &test.MockClient{
+					MockGet:    withAnnotations(map[string]string{AnnotationKeyPollInterval: "10s"}),
+					MockScheme: test.NewMockSchemeFn(s),
+				},
+				inner: reconcile.Func(func(_ context.Context, _ reconcile.Request) (reconcile.Result, error) {
+					return reconcile.Result{Requeue: true}, nil
+				}),
+			},
+			want: want{result: reconcile.Result{Requeue: true}},
+		},
+		"InnerError": {
+			reason: "We should return any error returned by the inner reconciler.",
+			args: args{
+				kube: &test.MockClient{
+					MockGet:    withAnnotations(map[string]string{AnnotationKeyPollInterval: "10s"}),
+					MockScheme: test.NewMockSchemeFn(s),
+				},
+				inner: reconcile.Func(func(_ context.Context, _ reconcile.Request) (reconcile.Result, error) {
+					return reconcile.Result{RequeueAfter: interval}, errBoom
+				}),
+			},
+			want: want{result: reconcile.Result{RequeueAfter: interval}, err: errBoom},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			r := NewReconciler(tc.args.kube, resource.ManagedKind(v1alpha1.MyTypeGroupVersionKind), tc.args.inner, interval)
+			got, err := r.Reconcile(context.Background(), reconcile.Request{})
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nr.Reconcile(...): -want error, +got error:\n%s\n", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.result, got); diff != "" {
+				t.Errorf("\n%s\nr.Reconcile(...): -want, +got:\n%s\n", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestReconcilePublishConnectionDetailsError(t *testing.T) {
+	errBoom := errors.New("boom")
+	interval := time.Minute
+
+	s := runtime.NewScheme()
+	if err := v1alpha1.SchemeBuilder.AddToScheme(s); err != nil {
+		t.Fatalf("cannot build scheme: %s", err)
+	}
+
+	// The managed resource is up to date, but publishing its connection
+	// details fails, so it was not successfully reconciled.
+	kube := &test.MockClient{
+		MockGet: test.NewMockGetFn(nil, func(obj client.Object) error {
+			mt := obj.(*v1alpha1.MyType)
+			mt.SetGeneration(3)
+			mt.Status.ObservedGeneration = 2
+			return nil
+		}),
+		MockUpdate: test.NewMockUpdateFn(nil),
+		MockStatusUpdate: func(_ context.Context, obj client.Object, _ ...client.UpdateOption) error {
+			mt := obj.(*v1alpha1.MyType)
+			if got := mt.GetCondition(xpv1.TypeSynced); got.Reason != xpv1.ReasonReconcileError {
+				t.Errorf("kube.Status().Update(...): want %s condition, got %+v", xpv1.ReasonReconcileError, got)
+			}
+			if got := mt.Status.ObservedGeneration; got != 2 {
+				t.Errorf("kube.Status().Update(...): want observed generation 2, got %d", got)
+			}
+			return nil
+		},
+		MockScheme: test.NewMockSchemeFn(s),
+	}
+
+	of := resource.ManagedKind(v1alpha1.MyTypeGroupVersionKind)
+	inner := managed.NewReconciler(&fake.Manager{Client: kube, Scheme: s}, of,
+		managed.WithPollInterval(interval),
+		managed.WithExternalConnecter(managed.ExternalConnectorFn(func(_ context.Context, _ resource.Managed) (managed.ExternalClient, error) {
+			return &managed.ExternalClientFns{
+				ObserveFn: func(_ context.Context, _ resource.Managed) (managed.ExternalObservation, error) {
+					return managed.ExternalObservation{ResourceExists: true, ResourceUpToDate: true}, nil
+				},
+			}, nil
+		})),
+		managed.WithConnectionPublishers(managed.ConnectionPublisherFns{
+			PublishConnectionFn: func(_ context.Context, _ resource.Managed, _ managed.ConnectionDetails) error {
+				return errBoom
+			},
+		}))
+
+	r := NewReconciler(kube, of, inner, interval)
+	got, err := r.Reconcile(context.Background(), reconcile.Request{})
+	if err != nil {
+		t.Errorf("r.Reconcile(...): unexpected error: %s", err)
+	}
+	if diff := cmp.Diff(reconcile.Result{Requeue: true}, got); diff != "" {
+		t.Errorf("r.Reconcile(...): -want, +got:\n%s\n", diff)
+	}
+}
@@ -17,21 +17,124 @@ limitations under the License.
 package controller
 
 import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"k8s.io/client-go/util/workqueue"
+
 	"github.com/crossplane/crossplane-runtime/pkg/controller"
+	"github.com/crossplane/crossplane-runtime/pkg/ratelimiter"
 	ctrl "sigs.k8s.io/controller-runtime"
 
+	samplev1alpha1 "github.com/crossplane/provider-template/apis/sample/v1alpha1"
+	"github.com/crossplane/provider-template/apis/v1alpha1"
 	"github.com/crossplane/provider-template/internal/controller/config"
 	"github.com/crossplane/provider-template/internal/controller/mytype"
 )
 
+const (
+	errUnknownKindFmt     = "cannot override options for unknown kind %q: must be one of %s"
+	errInvalidOverrideFmt = "invalid options override for kind %q: values must be positive"
+	errUnusedOverrideFmt  = "cannot override the %s of kind %q: its controller does not use it"
+	errRateAboveMaxFmt    = "invalid options override for kind %q: max reconcile rate %d exceeds the global maximum of %d"
+)
+
+// A KindOverride overrides the controller.Options used to set up the
+// controller of a particular kind. Zero values are not overridden.
+type KindOverride struct {
+	// PollInterval at which resources of this kind are checked for drift.
+	PollInterval time.Duration
+
+	// MaxReconcileRate is the maximum rate per second at which resources of
+	// this kind may be reconciled. It may not exceed the global maximum
+	// reconcile rate, and can only lower the rate: reconciles of this kind
+	// remain subject to the global rate limiter that all kinds share.
+	MaxReconcileRate int
+}
+
+// Overridable indicates which KindOverride fields a kind's controller uses,
+// and thus may be overridden.
+type Overridable struct {
+	PollInterval     bool
+	MaxReconcileRate bool
+}
+
+// KindOverrides are KindOverride keyed by kind, e.g. MyType.
+type KindOverrides map[string]KindOverride
+
+// Validate returns an error if any override is not for one of the supplied
+// kinds, overrides nothing, overrides a field the kind's controller doesn't
+// use, has a negative value, or raises the max reconcile rate above the
+// supplied global maximum.
+func (ko KindOverrides) Validate(maxReconcileRate int, kinds map[string]Overridable) error {
+	for kind, ov := range ko {
+		can, ok := kinds[kind]
+		if !ok {
+			names := make([]string, 0, len(kinds))
+			for k := range kinds {
+				names = append(names, k)
+			}
+			sort.Strings(names)
+			return errors.Errorf(errUnknownKindFmt, kind, strings.Join(names, ", "))
+		}
+		if ov.PollInterval != 0 && !can.PollInterval {
+			return errors.Errorf(errUnusedOverrideFmt, "poll interval", kind)
+		}
+		if ov.MaxReconcileRate != 0 && !can.MaxReconcileRate {
+			return errors.Errorf(errUnusedOverrideFmt, "max reconcile rate", kind)
+		}
+		if ov.PollInterval < 0 || ov.MaxReconcileRate < 0 || ov == (KindOverride{}) {
+			return errors.Errorf(errInvalidOverrideFmt, kind)
+		}
+		if ov.MaxReconcileRate > maxReconcileRate {
+			return errors.Errorf(errRateAboveMaxFmt, kind, ov.MaxReconcileRate, maxReconcileRate)
+		}
+	}
+	return nil
+}
+
+// Apply returns a copy of the supplied options with the override for the
+// supplied kind, if any, applied.
+func (ko KindOverrides) Apply(kind string, o controller.Options) controller.Options {
+	ov, ok := ko[kind]
+	if !ok {
+		return o
+	}
+	if ov.PollInterval > 0 {
+		o.PollInterval = ov.PollInterval
+	}
+	if ov.MaxReconcileRate > 0 {
+		o.GlobalRateLimiter = workqueue.NewMaxOfRateLimiter(o.GlobalRateLimiter, ratelimiter.NewGlobal(ov.MaxReconcileRate))
+	}
+	return o
+}
+
 // Setup creates all Template controllers with the supplied logger and adds them to
-// the supplied manager.
-func Setup(mgr ctrl.Manager, o controller.Options) error {
-	for _, setup := range []func(ctrl.Manager, controller.Options) error{
-		config.Setup,
-		mytype.Setup,
-	} {
-		if err := setup(mgr, o); err != nil {
+// the supplied manager. Each controller is set up with the supplied options,
+// subject to any overrides for its kind. The supplied options' global rate
+// limiter must limit reconciles to the supplied max reconcile rate.
+func Setup(mgr ctrl.Manager, o controller.Options, maxReconcileRate int, ko KindOverrides) error {
+	controllers := []struct {
+		kind  string
+		setup func(ctrl.Manager, controller.Options) error
+		can   Overridable
+	}{
+		{kind: v1alpha1.ProviderConfigKind, setup: config.Setup, can: Overridable{MaxReconcileRate: true}},
+		{kind: samplev1alpha1.MyTypeKind, setup: mytype.Setup, can: Overridable{PollInterval: true, MaxReconcileRate: true}},
+	}
+
+	kinds := make(map[string]Overridable, len(controllers))
+	for _, c := range controllers {
+		kinds[c.kind] = c.can
+	}
+	if err := ko.Validate(maxReconcileRate, kinds); err != nil {
+		return err
+	}
+
+	for _, c := range controllers {
+		if err := c.setup(mgr, ko.Apply(c.kind, o)); err != nil {
 			return err
 		}
 	}
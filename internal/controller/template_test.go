@@ -0,0 +1,153 @@
+/*
+Copyright 2022 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/pkg/errors"
+
+	"github.com/crossplane/crossplane-runtime/pkg/controller"
+	"github.com/crossplane/crossplane-runtime/pkg/ratelimiter"
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+)
+
+func TestKindOverridesValidate(t *testing.T) {
+	kinds := map[string]Overridable{
+		"ProviderConfig": {MaxReconcileRate: true},
+		"MyType":         {PollInterval: true, MaxReconcileRate: true},
+	}
+
+	cases := map[string]struct {
+		reason string
+		ko     KindOverrides
+		want   error
+	}{
+		"Valid": {
+			reason: "Positive overrides for known kinds should be valid.",
+			ko:     KindOverrides{"MyType": {PollInterval: time.Minute}, "ProviderConfig": {MaxReconcileRate: 1}},
+		},
+		"UnknownKind": {
+			reason: "An override for a kind that isn't set up should be invalid.",
+			ko:     KindOverrides{"Mytype": {PollInterval: time.Minute}},
+			want:   errors.Errorf(errUnknownKindFmt, "Mytype", "MyType, ProviderConfig"),
+		},
+		"UnusedField": {
+			reason: "An override of a field the kind's controller doesn't use should be invalid.",
+			ko:     KindOverrides{"ProviderConfig": {PollInterval: 5 * time.Minute}},
+			want:   errors.Errorf(errUnusedOverrideFmt, "poll interval", "ProviderConfig"),
+		},
+		"RateAboveGlobalMax": {
+			reason: "An override that would raise a kind's max reconcile rate above the global maximum should be invalid.",
+			ko:     KindOverrides{"MyType": {MaxReconcileRate: 50}},
+			want:   errors.Errorf(errRateAboveMaxFmt, "MyType", 50, 10),
+		},
+		"NegativeValue": {
+			reason: "An override with a negative value should be invalid.",
+			ko:     KindOverrides{"MyType": {PollInterval: time.Minute, MaxReconcileRate: -1}},
+			want:   errors.Errorf(errInvalidOverrideFmt, "MyType"),
+		},
+		"EmptyOverride": {
+			reason: "An override that overrides nothing should be invalid.",
+			ko:     KindOverrides{"MyType": {}},
+			want:   errors.Errorf(errInvalidOverrideFmt, "MyType"),
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			err := tc.ko.Validate(10, kinds)
+			if diff := cmp.Diff(tc.want, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nko.Validate(...): -want error, +got error:\n%s\n", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestKindOverridesApply(t *testing.T) {
+	type args struct {
+		kind string
+
+		// drainGlobal uses up the global rate limiter's burst before the
+		// kind's rate limiter is used.
+		drainGlobal bool
+	}
+
+	type want struct {
+		pollInterval time.Duration
+
+		// burst is how many reconciles are allowed before one is delayed.
+		burst int
+	}
+
+	cases := map[string]struct {
+		reason string
+		ko     KindOverrides
+		args   args
+		want   want
+	}{
+		"NoOverride": {
+			reason: "A kind without an override should use the supplied options.",
+			ko:     KindOverrides{"Other": {PollInterval: 10 * time.Minute, MaxReconcileRate: 1}},
+			args:   args{kind: "MyType"},
+			want:   want{pollInterval: time.Minute, burst: 20},
+		},
+		"PollIntervalOverride": {
+			reason: "A kind with a poll interval override should use it, but keep the global rate limiter.",
+			ko:     KindOverrides{"MyType": {PollInterval: 10 * time.Minute}},
+			args:   args{kind: "MyType"},
+			want:   want{pollInterval: 10 * time.Minute, burst: 20},
+		},
+		"MaxReconcileRateOverride": {
+			reason: "A kind with a max reconcile rate override should be limited to that rate.",
+			ko:     KindOverrides{"MyType": {MaxReconcileRate: 1}},
+			args:   args{kind: "MyType"},
+			want:   want{pollInterval: time.Minute, burst: 10},
+		},
+		"MaxReconcileRateOverrideSharesGlobal": {
+			reason: "A kind with a max reconcile rate override should remain subject to the global rate limiter.",
+			ko:     KindOverrides{"MyType": {MaxReconcileRate: 1}},
+			args:   args{kind: "MyType", drainGlobal: true},
+			want:   want{pollInterval: time.Minute, burst: 0},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			global := ratelimiter.NewGlobal(2)
+			got := tc.ko.Apply(tc.args.kind, controller.Options{PollInterval: time.Minute, GlobalRateLimiter: global})
+			if diff := cmp.Diff(tc.want.pollInterval, got.PollInterval); diff != "" {
+				t.Errorf("\n%s\nko.Apply(...): -want poll interval, +got poll interval:\n%s\n", tc.reason, diff)
+			}
+
+			if tc.args.drainGlobal {
+				for global.When("other") == 0 {
+				}
+			}
+
+			burst := 0
+			for got.GlobalRateLimiter.When("cool") == 0 && burst < 100 {
+				burst++
+			}
+			if diff := cmp.Diff(tc.want.burst, burst); diff != "" {
+				t.Errorf("\n%s\nko.Apply(...): -want burst, +got burst:\n%s\n", tc.reason, diff)
+			}
+		})
+	}
+}